@@ -16,32 +16,34 @@ limitations under the License.
 package apisrv
 
 import (
-	"go.opencensus.io/stats"
-	"go.opencensus.io/stats/view"
-	"go.opencensus.io/tag"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
-// OpenCensus Measures. These are exported as metrics to your monitoring system
-// https://godoc.org/go.opencensus.io/stats
+// OpenTelemetry instruments. These are exported as metrics to your monitoring
+// system through whichever Reader is attached to the backend API's
+// MeterProvider - see meterprovider.go for how operators pick a Prometheus,
+// OTLP, or Stackdriver/GCM exporter via configuration.
 //
-// When making opencensus stats, the 'name' param, with forward slashes changed
-// to underscores, is appended to the 'namespace' value passed to the
-// prometheus exporter to become the Prometheus metric name. You can also look
-// into having Prometheus rewrite your metric names on scrape.
+// Instrument names are kept identical to the OpenCensus view names this
+// package used to register (e.g. "backend/assignments") so existing
+// Prometheus scrape configs and dashboards built against the
+// "backend_assignments_total" series keep working unchanged across the
+// migration. As before, the 'name' param, with forward slashes changed to
+// underscores, is appended to the namespace configured on the exporter to
+// become the Prometheus metric name.
 //
 //  For example:
-//   - defining the promethus export namespace "open_match" when instanciating the exporter:
-//			pe, err := promethus.NewExporter(promethus.Options{Namespace: "open_match"})
-//   - and naming the request counter "backend/requests_total":
-//			MGrpcRequests := stats.Int64("backendapi/requests_total", ...
+//   - configuring the prometheus export namespace "open_match" on the
+//     exporter returned by meterprovider.go,
+//   - and naming the assignments counter "backend/assignments" (below),
 //   - results in the prometheus metric name:
-//			open_match_backendapi_requests_total
-//   - [note] when using opencensus views to aggregate the metrics into
-//     distribution buckets and such, multiple metrics
-//     will be generated with appended types ("<metric>_bucket",
-//     "<metric>_count", "<metric>_sum", for example)
+//			open_match_backend_assignments_total
 //
-// In addition, OpenCensus stats propogated to Prometheus have the following
+// In addition, metrics exported to Prometheus have the following
 // auto-populated labels pulled from kubernetes, which we should avoid to
 // prevent overloading and having to use the HonorLabels param in Prometheus.
 //
@@ -59,82 +61,99 @@ var (
 	// the logrus hook provided in metrics/helper.go after instantiating the
 	// logrus instance in your application code.
 	// https://godoc.org/github.com/sirupsen/logrus#LevelHooks
-	BeLogLines = stats.Int64("backendapi/logs_total", "Number of Backend API lines logged", "1")
+	BeLogLines = newInt64Measure("log_lines/total", "Number of Backend API lines logged", KeySeverity)
 
 	// Failure instrumentation
-	BeFailures = stats.Int64("backendapi/failures_total", "Number of Backend API failures", "1")
+	BeFailures = newInt64Measure("failures", "Number of Backend API failures", KeyMethod)
 
 	// Counting operations
-	BeAssignments                = stats.Int64("backendapi/assignments_total", "Number of players assigned to matches", "1")
-	BeAssignmentFailures         = stats.Int64("backendapi/assignment/failures_total", "Number of player match assigment failures", "1")
-	BeAssignmentDeletions        = stats.Int64("backendapi/assignment/deletions_total", "Number of player match assigment deletions", "1")
-	BeAssignmentDeletionFailures = stats.Int64("backendapi/assignment/deletions/failures_total", "Number of player match assigment deletion failures", "1")
+	BeAssignments                = newInt64Measure("backend/assignments", "Number of players assigned to matches", KeyMethod)
+	BeAssignmentFailures         = newInt64Measure("backend/assignments/failures", "Number of player match assigment failures", KeyMethod)
+	BeAssignmentDeletions        = newInt64Measure("backend/assignments/deletions", "Number of player match assigment deletions", KeyMethod)
+	BeAssignmentDeletionFailures = newInt64Measure("backend/assignments/deletions/failures", "Number of player match assigment deletion failures", KeyMethod)
 )
 
 var (
-	// KeyMethod is used to tag a measure with the currently running API method.
-	KeyMethod, _ = tag.NewKey("method")
-	// KeySeverity is used to tag a the severity of a log message.
-	KeySeverity, _ = tag.NewKey("severity")
+	// KeyMethod tags a measure with the currently running API method.
+	KeyMethod = attribute.Key("method")
+	// KeySeverity tags a measure with the severity of a log message.
+	KeySeverity = attribute.Key("severity")
 )
 
-// Package metrics provides some convience views.
-// You need to register the views for the data to actually be collected.
-// Note: The OpenCensus View 'Description' is exported to Prometheus as the HELP string.
-// Note: If you get a "Failed to export to Prometheus: inconsistent label
-// cardinality" error, chances are you forgot to set the tags specified in the
-// view for a given measure when you tried to do a stats.Record()
-var (
-	BeLogCountView = &view.View{
-		Name:        "log_lines/total",
-		Measure:     BeLogLines,
-		Description: "The number of lines logged",
-		Aggregation: view.Count(),
-		TagKeys:     []tag.Key{KeySeverity},
-	}
+// meter is the package-wide OpenTelemetry Meter every backend API instrument
+// is created from. It's bound to whatever MeterProvider is installed via
+// otel.SetMeterProvider - typically by meterprovider.go's NewMeterProvider
+// during server bootstrap. Instruments created before that call still work:
+// the global otel package hands back a delegating Meter that forwards to the
+// real one as soon as it's set.
+//
+// Passing scopeName/scopeVersion (scope.go) here is what lets the Prometheus
+// exporter tell this package's metrics apart from a same-shaped counter
+// emitted by another open-match subsystem sharing the same MeterProvider -
+// see scope.go for why that's done through the Meter itself rather than by
+// hand-rolling otel_scope_name/otel_scope_version attributes.
+var meter = otel.Meter(scopeName, metric.WithInstrumentationVersion(scopeVersion))
 
-	BeFailureCountView = &view.View{
-		Name:        "failures",
-		Measure:     BeFailures,
-		Description: "The number of failures",
-		Aggregation: view.Count(),
-	}
+// Int64Measure is a named, described OpenTelemetry counter instrument along
+// with the attribute keys it's expected to be recorded with. It plays the
+// role the OpenCensus (measure, view) pair used to: Name/Description are
+// what a Reader exports, and tagKeys is consulted by MetricsRegistry's typed
+// recorders (see validateAttrs in registry.go) each time they record, so
+// mismatched attribute usage panics at the call instead of surfacing as a
+// Prometheus scrape-time cardinality error. It is not checked when a
+// MetricsRegistry is constructed.
+type Int64Measure struct {
+	name        string
+	description string
+	tagKeys     []attribute.Key
+	counter     metric.Int64Counter
+}
 
-	BeAssignmentCountView = &view.View{
-		Name:        "backend/assignments",
-		Measure:     BeAssignments,
-		Description: "The number of successful player match assignments",
-		Aggregation: view.Count(),
-	}
+// M builds a Measurement carrying delta v, mirroring the OpenCensus
+// stats.Int64.M method so existing call sites migrate with minimal churn.
+func (i Int64Measure) M(v int64) Measurement {
+	return Measurement{measure: i, value: v}
+}
 
-	BeAssignmentFailureCountView = &view.View{
-		Name:        "backend/assignments/failures",
-		Measure:     BeAssignmentFailures,
-		Description: "The number of player match assignment failures",
-		Aggregation: view.Count(),
-	}
+// metricName satisfies measure so Int64Measure and Float64Measure (see
+// latency.go) can share a single DefaultBackendAPIViews slice.
+func (i Int64Measure) metricName() string { return i.name }
 
-	BeAssignmentDeletionCountView = &view.View{
-		Name:        "backend/assignments/deletions",
-		Measure:     BeAssignmentDeletions,
-		Description: "The number of successful player match assignments",
-		Aggregation: view.Count(),
+// newInt64Measure registers an Int64Counter instrument named name against
+// the package meter. Instrument construction only fails on programmer error
+// (an invalid name or duplicate registration against the same meter), so a
+// failure here is treated like any other package-level initialization bug.
+func newInt64Measure(name, description string, tagKeys ...attribute.Key) Int64Measure {
+	c, err := meter.Int64Counter(name, metric.WithDescription(description), metric.WithUnit("1"))
+	if err != nil {
+		panic(fmt.Sprintf("apisrv: registering metric %q: %v", name, err))
 	}
+	return Int64Measure{name: name, description: description, tagKeys: tagKeys, counter: c}
+}
 
-	BeAssignmentDeletionFailureCountView = &view.View{
-		Name:        "backend/assignments/deletions/failures",
-		Measure:     BeAssignmentDeletionFailures,
-		Description: "The number of player match assignment failures",
-		Aggregation: view.Count(),
-	}
-)
+// measure is satisfied by every instrument wrapper this package defines
+// (Int64Measure here, Float64Measure in latency.go), so DefaultBackendAPIViews
+// can hold both counters and histograms in one slice.
+type measure interface {
+	metricName() string
+}
 
-// DefaultBackendAPIViews are the default backend API OpenCensus measure views.
-var DefaultBackendAPIViews = []*view.View{
-	BeLogCountView,
-	BeFailureCountView,
-	BeAssignmentCountView,
-	BeAssignmentFailureCountView,
-	BeAssignmentDeletionCountView,
-	BeAssignmentDeletionFailureCountView,
+// DefaultBackendAPIViews lists every backend API instrument (the counters
+// under their OpenCensus-era view names, plus the latency histograms from
+// latency.go), for anything that wants to enumerate them (logging
+// what's active at startup, building an equivalent list for another
+// exporter, etc). Unlike OpenCensus views, none of these need to be
+// registered for a Reader to start collecting them - anything created from
+// meter is automatically visible to whatever Reader the installed
+// MeterProvider holds.
+var DefaultBackendAPIViews = []measure{
+	BeLogLines,
+	BeFailures,
+	BeAssignments,
+	BeAssignmentFailures,
+	BeAssignmentDeletions,
+	BeAssignmentDeletionFailures,
+	BeAssignmentLatencyMs,
+	BeAssignmentDeletionLatencyMs,
+	BeCreateMatchLatencyMs,
 }
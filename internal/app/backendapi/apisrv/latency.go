@@ -0,0 +1,124 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package apisrv
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+)
+
+// defaultLatencyBucketsMs are the Distribution boundaries, in milliseconds,
+// every backend API latency histogram uses. They're spaced for
+// sub-2.5-second game-server matchmaking RPCs, with enough low-end
+// resolution (1, 2.5, 5ms) to see p50/p95 shift before a regression grows
+// large enough to show up in the totals-only counters in backend_stats.go.
+var defaultLatencyBucketsMs = []float64{1, 2.5, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
+
+// Float64Measure is a named, described OpenTelemetry histogram instrument,
+// the Float64/Distribution counterpart to Int64Measure in backend_stats.go.
+type Float64Measure struct {
+	name        string
+	description string
+	tagKeys     []attribute.Key
+	histogram   metric.Float64Histogram
+}
+
+// metricName satisfies measure.
+func (f Float64Measure) metricName() string { return f.name }
+
+// newFloat64Histogram registers a Float64Histogram instrument named name,
+// bucketed at bucketBoundariesMs, against the package meter. name already
+// ends in "_ms" (it's part of the Prometheus-facing name we're preserving),
+// so no WithUnit is set here: the Prometheus exporter appends its own
+// "_milliseconds" unit suffix for unit "ms" unless the name already ends in
+// exactly that suffix, which would otherwise double up into
+// "..._latency_ms_milliseconds".
+func newFloat64Histogram(name, description string, bucketBoundariesMs []float64, tagKeys ...attribute.Key) Float64Measure {
+	h, err := meter.Float64Histogram(name,
+		metric.WithDescription(description),
+		metric.WithExplicitBucketBoundaries(bucketBoundariesMs...),
+	)
+	if err != nil {
+		panic(fmt.Sprintf("apisrv: registering metric %q: %v", name, err))
+	}
+	return Float64Measure{name: name, description: description, tagKeys: tagKeys, histogram: h}
+}
+
+// Backend API latency histograms. Where the counters in backend_stats.go
+// only answer "how many", these answer "how long", enabling p50/p95/p99 SLO
+// dashboards on the same Prometheus exporter already configured for the
+// counts.
+var (
+	BeAssignmentLatencyMs         = newFloat64Histogram("backend/assignments/latency_ms", "Latency of player match assignment RPCs, in milliseconds", defaultLatencyBucketsMs, KeyMethod)
+	BeAssignmentDeletionLatencyMs = newFloat64Histogram("backend/assignments/deletions/latency_ms", "Latency of player match assignment deletion RPCs, in milliseconds", defaultLatencyBucketsMs, KeyMethod)
+	BeCreateMatchLatencyMs        = newFloat64Histogram("backend/matches/create/latency_ms", "End-to-end latency of the CreateMatch RPC, in milliseconds", defaultLatencyBucketsMs, KeyMethod)
+)
+
+// recordLatency validates attrs against measure's declared tagKeys, the
+// Float64Measure counterpart to MetricsRegistry.recordTagged.
+func (r *MetricsRegistry) recordLatency(ctx context.Context, measure Float64Measure, valueMs float64, attrs ...attribute.KeyValue) {
+	validateAttrs(measure.name, measure.tagKeys, attrs)
+	measure.histogram.Record(ctx, valueMs, metric.WithAttributes(attrs...))
+}
+
+// RecordAssignmentLatency records how long an assignment RPC served by
+// method took.
+func (r *MetricsRegistry) RecordAssignmentLatency(ctx context.Context, method string, d time.Duration) {
+	r.recordLatency(ctx, BeAssignmentLatencyMs, float64(d.Microseconds())/1000, KeyMethod.String(method))
+}
+
+// RecordAssignmentDeletionLatency records how long an assignment-deletion
+// RPC served by method took.
+func (r *MetricsRegistry) RecordAssignmentDeletionLatency(ctx context.Context, method string, d time.Duration) {
+	r.recordLatency(ctx, BeAssignmentDeletionLatencyMs, float64(d.Microseconds())/1000, KeyMethod.String(method))
+}
+
+// RecordCreateMatchLatency records the end-to-end latency of a CreateMatch
+// call served by method.
+func (r *MetricsRegistry) RecordCreateMatchLatency(ctx context.Context, method string, d time.Duration) {
+	r.recordLatency(ctx, BeCreateMatchLatencyMs, float64(d.Microseconds())/1000, KeyMethod.String(method))
+}
+
+// latencyMethods maps a gRPC FullMethod to the MetricsRegistry recorder
+// that should time it. UnaryServerInterceptor only instruments the methods
+// listed here; anything else passes through untimed.
+var latencyMethods = map[string]func(*MetricsRegistry, context.Context, string, time.Duration){
+	"/api.Backend/AssignTickets":     (*MetricsRegistry).RecordAssignmentLatency,
+	"/api.Backend/DeleteAssignments": (*MetricsRegistry).RecordAssignmentDeletionLatency,
+	"/api.Backend/CreateMatch":       (*MetricsRegistry).RecordCreateMatchLatency,
+}
+
+// UnaryServerInterceptor times each backend API RPC listed in
+// latencyMethods and records it into the matching latency histogram,
+// tagged by the RPC's method name.
+func (r *MetricsRegistry) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		record, ok := latencyMethods[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		record(r, ctx, info.FullMethod, time.Since(start))
+		return resp, err
+	}
+}
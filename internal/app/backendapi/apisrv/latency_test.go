@@ -0,0 +1,70 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package apisrv
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+func TestLatencyMethodsCoversEveryHistogram(t *testing.T) {
+	want := []string{
+		"/api.Backend/AssignTickets",
+		"/api.Backend/DeleteAssignments",
+		"/api.Backend/CreateMatch",
+	}
+	if len(latencyMethods) != len(want) {
+		t.Fatalf("len(latencyMethods) = %d, want %d", len(latencyMethods), len(want))
+	}
+	for _, method := range want {
+		if _, ok := latencyMethods[method]; !ok {
+			t.Errorf("latencyMethods is missing an entry for %q", method)
+		}
+	}
+}
+
+func TestUnaryServerInterceptorRoutesKnownAndUnknownMethods(t *testing.T) {
+	r := NewMetricsRegistry()
+	interceptor := r.UnaryServerInterceptor()
+
+	handlerCalled := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		handlerCalled = true
+		return "ok", nil
+	}
+
+	methods := []string{
+		"/api.Backend/AssignTickets",
+		"/api.Backend/DeleteAssignments",
+		"/api.Backend/CreateMatch",
+		"/api.Backend/SomeUntimedMethod",
+	}
+	for _, method := range methods {
+		handlerCalled = false
+		resp, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		if err != nil {
+			t.Fatalf("interceptor(%s) returned error %v, want nil", method, err)
+		}
+		if resp != "ok" {
+			t.Errorf("interceptor(%s) resp = %v, want %q", method, resp, "ok")
+		}
+		if !handlerCalled {
+			t.Errorf("interceptor(%s) never invoked the wrapped handler", method)
+		}
+	}
+}
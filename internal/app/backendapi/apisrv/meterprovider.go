@@ -0,0 +1,94 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package apisrv
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetricgrpc"
+	"go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// Exporter selects which metrics backend the backend API's MeterProvider is
+// wired to. Operators pick one via configuration (see config/config.go,
+// key "metrics.exporter"); this package doesn't hardcode a single transport
+// the way it did when it only ever supported an OpenCensus Prometheus
+// exporter.
+type Exporter string
+
+const (
+	// ExporterPrometheus serves a /metrics endpoint for in-cluster scraping.
+	// This is the default, matching the OpenCensus-era behavior.
+	ExporterPrometheus Exporter = "prometheus"
+	// ExporterOTLP pushes to an OpenTelemetry Collector over gRPC, which can
+	// in turn fan out to Stackdriver/GCM (via its googlemanagedprometheus or
+	// googlecloud exporter), a vendor backend, or anywhere else OTLP is
+	// accepted. Use this for Stackdriver/GCM rather than talking to GCM
+	// directly - there's no first-party GCM exporter in the Go SDK.
+	ExporterOTLP Exporter = "otlp"
+)
+
+// MeterProviderConfig configures NewMeterProvider.
+type MeterProviderConfig struct {
+	// Exporter selects the Reader backing the MeterProvider. Defaults to
+	// ExporterPrometheus when empty.
+	Exporter Exporter
+	// OTLPEndpoint is the collector address used when Exporter is
+	// ExporterOTLP, e.g. "otel-collector.open-match.svc:4317".
+	OTLPEndpoint string
+	// Namespace is prepended to every Prometheus metric name when Exporter
+	// is ExporterPrometheus, e.g. "open_match" turns "backend/assignments"
+	// into "open_match_backend_assignments_total" - see the doc comment
+	// atop backend_stats.go.
+	Namespace string
+}
+
+// NewMeterProvider builds the metric.MeterProvider for the backend API
+// according to cfg and installs it as the global provider via
+// otel.SetMeterProvider, so meter (backend_stats.go) and every instrument
+// created from it are collected by the configured Reader. The returned func
+// flushes and closes that Reader; callers should defer it during server
+// shutdown.
+func NewMeterProvider(ctx context.Context, cfg MeterProviderConfig) (func(context.Context) error, error) {
+	res := resource.NewSchemaless(semconv.ServiceNameKey.String("open-match-backendapi"))
+
+	var reader sdkmetric.Reader
+	switch cfg.Exporter {
+	case ExporterPrometheus, "":
+		exp, err := prometheus.New(prometheus.WithNamespace(cfg.Namespace))
+		if err != nil {
+			return nil, fmt.Errorf("apisrv: creating prometheus exporter: %w", err)
+		}
+		reader = exp
+	case ExporterOTLP:
+		exp, err := otlpmetricgrpc.New(ctx, otlpmetricgrpc.WithEndpoint(cfg.OTLPEndpoint), otlpmetricgrpc.WithInsecure())
+		if err != nil {
+			return nil, fmt.Errorf("apisrv: creating otlp metric exporter: %w", err)
+		}
+		reader = sdkmetric.NewPeriodicReader(exp)
+	default:
+		return nil, fmt.Errorf("apisrv: unknown metrics exporter %q", cfg.Exporter)
+	}
+
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithResource(res), sdkmetric.WithReader(reader))
+	otel.SetMeterProvider(mp)
+	return mp.Shutdown, nil
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package apisrv
+
+import (
+	"context"
+
+	"go.opencensus.io/tag"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// This file is a temporary shim over the OpenTelemetry instruments in
+// backend_stats.go that mirrors the old OpenCensus idiom:
+//
+//	stats.Record(ctx, BeAssignments.M(1))
+//
+// as Record(ctx, BeAssignments.M(1)) in this package. Int64Measure.M returns
+// this package's Measurement, not an OpenCensus stats.Measurement, so a call
+// site still passing it to go.opencensus.io/stats.Record does not compile;
+// it keeps its shape by calling Record (below) instead, until it moves to the
+// typed recorders on MetricsRegistry (see registry.go). Delete this file once
+// no call site uses Record anymore.
+
+// Measurement is a pending (instrument, delta) pair produced by
+// Int64Measure.M, mirroring OpenCensus's stats.Measurement.
+type Measurement struct {
+	measure Int64Measure
+	value   int64
+}
+
+// Record replays each Measurement against its OTel counter. Any OpenCensus
+// tags still set on ctx via tag.NewContext/tag.Upsert - the pattern call
+// sites used before this migration - are translated into OTel attributes so
+// behavior is unchanged; new code should set attributes explicitly via the
+// typed recorders instead of relying on ctx-carried tags. Every instrument
+// here is created from the scoped meter in backend_stats.go, so the
+// Prometheus exporter already attaches otel_scope_name/otel_scope_version
+// on export - see scope.go for why this package doesn't also attach them
+// itself.
+//
+// Unlike the typed recorders on MetricsRegistry, Record does not reject a
+// Measurement whose ctx doesn't carry every attribute its measure declares
+// in tagKeys: an un-migrated call site from before a measure grew a new tag
+// (e.g. BeAssignments picking up KeyMethod) has no way to supply it, and
+// this shim exists precisely so that call site keeps working rather than
+// crashing. Recording with a partial/empty attribute set reproduces the
+// same "inconsistent label cardinality" Prometheus scrape symptom this
+// package had before the OpenTelemetry migration - not ideal, but a latent
+// scrape-time symptom, not a panic, which is what "keep working" means
+// here. Migrate the call site to MetricsRegistry's typed recorders to get
+// the stricter, fail-fast behavior instead.
+func Record(ctx context.Context, ms ...Measurement) {
+	for _, m := range ms {
+		attrs := attributesForMeasure(ctx, m.measure.tagKeys)
+		m.measure.counter.Add(ctx, m.value, metric.WithAttributes(attrs...))
+	}
+}
+
+// attributesForMeasure resolves, in tagKeys order, the subset of the
+// OpenCensus tags this package ever set (KeyMethod, KeySeverity) that are
+// both present on ctx and declared by tagKeys, into their OTel attribute
+// equivalents. Scoping to tagKeys (rather than returning every tag found on
+// ctx) is what keeps Record from attaching a KeyMethod attribute to a
+// measure like BeLogLines, which only declares KeySeverity, even when ctx
+// also happens to carry a KeyMethod tag set by an unrelated call site
+// earlier in the request.
+func attributesForMeasure(ctx context.Context, tagKeys []attribute.Key) []attribute.KeyValue {
+	m := tag.FromContext(ctx)
+	if m == nil {
+		return nil
+	}
+
+	attrs := make([]attribute.KeyValue, 0, len(tagKeys))
+	for _, key := range tagKeys {
+		ocKey, ok := ocKeyFor(key)
+		if !ok {
+			continue
+		}
+		if v, ok := m.Value(ocKey); ok {
+			attrs = append(attrs, key.String(v))
+		}
+	}
+	return attrs
+}
+
+// ocKeyFor maps an OTel attribute key back to the OpenCensus tag.Key
+// carrying the same value on ctx, for the handful of keys this package ever
+// set as OpenCensus tags.
+func ocKeyFor(key attribute.Key) (tag.Key, bool) {
+	switch key {
+	case KeyMethod:
+		return ocKeyMethod, true
+	case KeySeverity:
+		return ocKeySeverity, true
+	default:
+		return tag.Key{}, false
+	}
+}
+
+// ocKeyMethod and ocKeySeverity are OpenCensus tag.Key equivalents of
+// KeyMethod/KeySeverity, kept only so ctx built with
+// tag.NewContext(ctx, tag.Upsert(...)) by not-yet-migrated call sites still
+// resolves to the right OTel attribute in attributesForMeasure above.
+var (
+	ocKeyMethod, _   = tag.NewKey(string(KeyMethod))
+	ocKeySeverity, _ = tag.NewKey(string(KeySeverity))
+)
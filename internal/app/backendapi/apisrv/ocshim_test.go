@@ -0,0 +1,54 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package apisrv
+
+import (
+	"context"
+	"testing"
+
+	"go.opencensus.io/tag"
+)
+
+// An un-migrated call site that never adopted tag.NewContext/tag.Upsert -
+// the common case, since most of this package's OpenCensus-era callers never
+// set tags at all - must keep working against a measure that has since
+// grown a required tag (BeAssignments picked up KeyMethod during the
+// OpenTelemetry migration), not panic.
+func TestRecordWithoutTagsDoesNotPanic(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("Record panicked on an un-migrated call site: %v", r)
+		}
+	}()
+	Record(context.Background(), BeAssignments.M(1))
+}
+
+// A call site that did migrate to tag.NewContext/tag.Upsert still keeps
+// working. This checks only that Record does not panic; the attribute the
+// tag is translated into is not inspected here.
+func TestRecordWithMatchingTagDoesNotPanic(t *testing.T) {
+	ctx, err := tag.New(context.Background(), tag.Upsert(ocKeyMethod, "AssignTickets"))
+	if err != nil {
+		t.Fatalf("tag.New() = _, %v, want nil error", err)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("Record panicked on a fully-tagged call site: %v", r)
+		}
+	}()
+	Record(ctx, BeAssignments.M(1))
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package apisrv
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// MetricsRegistry groups this package's typed Record* methods behind a
+// single receiver, so callers get a RecordAssignment(ctx, method string)
+// whose attribute set is fixed by its signature, instead of the raw
+// stats.Record(ctx, BeAssignments.M(1))-style call in ocshim.go, which
+// accepts whatever attributes happen to be on ctx and was never validated.
+//
+// An earlier version of this type also tried to dedupe repeated
+// construction for embedders running multiple backend API instances in one
+// process, mirroring a real panic risk OpenCensus's view.Register had. That
+// risk doesn't carry over to this design: every instrument in
+// backend_stats.go/latency.go is a package-level var created exactly once,
+// regardless of how many MetricsRegistry or backend API server values
+// exist, so there was nothing left for construction to dedupe or fail on.
+// NewMetricsRegistry reflects that honestly below rather than pretending to
+// guard against a problem this package no longer has.
+type MetricsRegistry struct{}
+
+// NewMetricsRegistry returns a MetricsRegistry ready to record against
+// DefaultBackendAPIViews. It cannot fail and is safe to call more than once.
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{}
+}
+
+// validateAttrs checks that attrs carries exactly the attribute keys in
+// tagKeys, in order, so a typed recorder call with a missing or
+// wrongly-ordered attribute panics immediately instead of reaching a
+// Prometheus "inconsistent label cardinality" scrape error. This is only
+// enforced for the typed Record* methods below, which fully control the
+// attributes they pass - ocshim.go's legacy Record shim accepts whatever
+// happens to be on ctx and does not call this, since un-migrated call
+// sites predating a measure's tagKeys can't be expected to supply them.
+func validateAttrs(name string, tagKeys []attribute.Key, attrs []attribute.KeyValue) {
+	if len(attrs) != len(tagKeys) {
+		panic(fmt.Sprintf("apisrv: %s: expected %d tag(s) %v, got %d", name, len(tagKeys), tagKeys, len(attrs)))
+	}
+	for i, want := range tagKeys {
+		if attrs[i].Key != want {
+			panic(fmt.Sprintf("apisrv: %s: expected tag %q at position %d, got %q", name, want, i, attrs[i].Key))
+		}
+	}
+}
+
+// recordTagged validates attrs against measure's declared tagKeys before
+// recording.
+func (r *MetricsRegistry) recordTagged(ctx context.Context, measure Int64Measure, value int64, attrs ...attribute.KeyValue) {
+	validateAttrs(measure.name, measure.tagKeys, attrs)
+	measure.counter.Add(ctx, value, metric.WithAttributes(attrs...))
+}
+
+// RecordLogLine records a single logged line at the given severity. Most
+// callers don't need this directly - use the logrus hook in
+// metrics/helper.go instead.
+func (r *MetricsRegistry) RecordLogLine(ctx context.Context, severity string) {
+	r.recordTagged(ctx, BeLogLines, 1, KeySeverity.String(severity))
+}
+
+// RecordFailure records a single backend API failure attributed to method.
+func (r *MetricsRegistry) RecordFailure(ctx context.Context, method string) {
+	r.recordTagged(ctx, BeFailures, 1, KeyMethod.String(method))
+}
+
+// RecordAssignment records a single successful player match assignment made
+// by method.
+func (r *MetricsRegistry) RecordAssignment(ctx context.Context, method string) {
+	r.recordTagged(ctx, BeAssignments, 1, KeyMethod.String(method))
+}
+
+// RecordAssignmentFailure records a single failed player match assignment
+// attempted by method.
+func (r *MetricsRegistry) RecordAssignmentFailure(ctx context.Context, method string) {
+	r.recordTagged(ctx, BeAssignmentFailures, 1, KeyMethod.String(method))
+}
+
+// RecordAssignmentDeletion records a single successful player match
+// assignment deletion made by method.
+func (r *MetricsRegistry) RecordAssignmentDeletion(ctx context.Context, method string) {
+	r.recordTagged(ctx, BeAssignmentDeletions, 1, KeyMethod.String(method))
+}
+
+// RecordAssignmentDeletionFailure records a single failed player match
+// assignment deletion attempted by method.
+func (r *MetricsRegistry) RecordAssignmentDeletionFailure(ctx context.Context, method string) {
+	r.recordTagged(ctx, BeAssignmentDeletionFailures, 1, KeyMethod.String(method))
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package apisrv
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestNewMetricsRegistry(t *testing.T) {
+	r := NewMetricsRegistry()
+	if r == nil {
+		t.Fatal("NewMetricsRegistry() returned nil")
+	}
+}
+
+func TestNewMetricsRegistrySafeToCallTwice(t *testing.T) {
+	// Every instrument is a package-level var created exactly once
+	// regardless of how many MetricsRegistry values exist, so constructing
+	// a second one for a second in-process backend API instance must not
+	// panic or otherwise misbehave.
+	NewMetricsRegistry()
+	NewMetricsRegistry()
+}
+
+func TestValidateAttrsPanicsOnMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("validateAttrs did not panic on a mismatched tag set")
+		}
+	}()
+	validateAttrs("test/measure", []attribute.Key{KeyMethod}, nil)
+}
+
+func TestValidateAttrsAcceptsMatchingSet(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Fatalf("validateAttrs panicked on a matching tag set: %v", r)
+		}
+	}()
+	validateAttrs("test/measure", []attribute.Key{KeyMethod}, []attribute.KeyValue{KeyMethod.String("AssignTickets")})
+}
+
+func TestRecordAssignmentDoesNotPanic(t *testing.T) {
+	r := NewMetricsRegistry()
+	r.RecordAssignment(context.Background(), "AssignTickets")
+}
@@ -0,0 +1,38 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package apisrv
+
+// This package identifies its metrics by OpenTelemetry instrumentation
+// scope rather than hand-rolling scope labels: backend_stats.go builds meter
+// via otel.Meter(scopeName, metric.WithInstrumentationVersion(scopeVersion)).
+// go.opentelemetry.io/otel/exporters/prometheus already joins
+// otel_scope_name/otel_scope_version onto every series from a scoped Meter,
+// and emits its own otel_scope_info gauge for it - that's what lets a
+// Prometheus instance scraping several open-match subsystems at once tell
+// "backend_assignments_total" apart from a same-shaped counter produced by
+// another component, without relying on the k8s-injected pod/job labels the
+// doc comment atop backend_stats.go warns against.
+//
+// Do not also attach otel_scope_name/otel_scope_version as explicit
+// attributes on individual recordings, and do not register a second,
+// hand-rolled otel_scope_info instrument: the exporter treats those as
+// constant labels that collide with the ones it derives from the Meter's
+// own scope, and drops the metric from Gather() with a
+// "duplicate label names in constant and variable labels" error.
+const (
+	scopeName    = "open-match/backendapi"
+	scopeVersion = "1.0.0"
+)
@@ -0,0 +1,182 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package apisrv
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-go/statsd"
+	"open-match.dev/open-match/internal/config"
+)
+
+// TagEncoding selects how StatsDExporter encodes an attribute like KeyMethod
+// onto a StatsD metric, since the StatsD wire protocol has no native concept
+// of labels the way Prometheus does.
+type TagEncoding string
+
+const (
+	// TagEncodingDatadog appends attributes as DogStatsD's native
+	// "name:value" tags. This is the default.
+	TagEncodingDatadog TagEncoding = "datadog"
+	// TagEncodingInflux folds attributes into the metric name using
+	// InfluxDB's dotted "measurement.tag=value" convention, for plain StatsD
+	// daemons (e.g. telegraf's statsd input) that don't speak DogStatsD tags.
+	TagEncodingInflux TagEncoding = "influx"
+)
+
+// StatsDExporterConfig configures NewStatsDExporter.
+type StatsDExporterConfig struct {
+	// Address is the StatsD/DogStatsD daemon to push to, e.g. "localhost:8125".
+	Address string
+	// Prefix is prepended to every metric name, StatsD's equivalent of the
+	// Prometheus exporter's namespace.
+	Prefix string
+	// FlushInterval batches writes instead of sending one UDP packet per
+	// measurement.
+	FlushInterval time.Duration
+	// TagEncoding selects how attributes are encoded. Defaults to
+	// TagEncodingDatadog.
+	TagEncoding TagEncoding
+}
+
+// StatsDExporterConfigFromConfig reads a StatsDExporterConfig out of cfg
+// under the "metrics.statsd" key, the same config.View every other backend
+// API dependency (Redis address, logging level, ...) is constructed from.
+func StatsDExporterConfigFromConfig(cfg config.View) StatsDExporterConfig {
+	encoding := TagEncoding(cfg.GetString("metrics.statsd.tagEncoding"))
+	if encoding == "" {
+		encoding = TagEncodingDatadog
+	}
+	return StatsDExporterConfig{
+		Address:       cfg.GetString("metrics.statsd.address"),
+		Prefix:        cfg.GetString("metrics.statsd.prefix"),
+		FlushInterval: cfg.GetDuration("metrics.statsd.flushInterval"),
+		TagEncoding:   encoding,
+	}
+}
+
+// StatsDExporter pushes backend API counts to a StatsD (or DogStatsD)
+// daemon, for operators whose game server fleet already aggregates metrics
+// that way instead of scraping Prometheus. It emits them under the names of
+// the Int64Measure instruments in backend_stats.go, but its Record* methods
+// are separate from MetricsRegistry's - call them from the same call sites,
+// alongside or instead of a MetricsRegistry. It covers BeLogLines and the
+// four BeAssignment* counters; BeFailures and the latency histograms in
+// latency.go are not pushed.
+type StatsDExporter struct {
+	client      *statsd.Client
+	tagEncoding TagEncoding
+}
+
+// NewStatsDExporter dials cfg.Address and returns a StatsDExporter that
+// flushes at cfg.FlushInterval. Buffering is the client's default behavior;
+// there's no separate opt-in option for it.
+func NewStatsDExporter(cfg StatsDExporterConfig) (*StatsDExporter, error) {
+	c, err := statsd.New(cfg.Address,
+		statsd.WithNamespace(cfg.Prefix),
+		statsd.WithoutTelemetry(),
+		statsd.WithBufferFlushInterval(cfg.FlushInterval),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("apisrv: dialing statsd at %q: %w", cfg.Address, err)
+	}
+	return &StatsDExporter{client: c, tagEncoding: cfg.TagEncoding}, nil
+}
+
+// RecordAssignment pushes a backend/assignments count, tagged by method.
+func (e *StatsDExporter) RecordAssignment(method string) error {
+	return e.recordCount(BeAssignments, 1, statsdTag{string(KeyMethod), method})
+}
+
+// RecordAssignmentFailure pushes a backend/assignments/failures count,
+// tagged by method.
+func (e *StatsDExporter) RecordAssignmentFailure(method string) error {
+	return e.recordCount(BeAssignmentFailures, 1, statsdTag{string(KeyMethod), method})
+}
+
+// RecordAssignmentDeletion pushes a backend/assignments/deletions count,
+// tagged by method.
+func (e *StatsDExporter) RecordAssignmentDeletion(method string) error {
+	return e.recordCount(BeAssignmentDeletions, 1, statsdTag{string(KeyMethod), method})
+}
+
+// RecordAssignmentDeletionFailure pushes a
+// backend/assignments/deletions/failures count, tagged by method.
+func (e *StatsDExporter) RecordAssignmentDeletionFailure(method string) error {
+	return e.recordCount(BeAssignmentDeletionFailures, 1, statsdTag{string(KeyMethod), method})
+}
+
+// RecordLogLine pushes a log_lines/total count, tagged by severity, so
+// downstream alerting rules that expect a per-severity log rate keep
+// working against the StatsD path the same way they already do against the
+// Prometheus one.
+func (e *StatsDExporter) RecordLogLine(severity string) error {
+	return e.recordCount(BeLogLines, 1, statsdTag{string(KeySeverity), severity})
+}
+
+// Close flushes any buffered metrics and closes the underlying connection.
+func (e *StatsDExporter) Close() error {
+	return e.client.Close()
+}
+
+// statsdTag is an (attribute key, value) pair pending encoding by
+// recordCount, since StatsD has no attribute.KeyValue equivalent of its own.
+type statsdTag struct {
+	key, value string
+}
+
+// recordCount emits measure as a StatsD counter ("c"), named after
+// measure.name with '/' replaced by '.' to match StatsD's dotted metric
+// naming convention, with tags encoded per e.tagEncoding.
+func (e *StatsDExporter) recordCount(measure Int64Measure, value int64, tags ...statsdTag) error {
+	name, ddTags := encodeTags(e.tagEncoding, statsdName(measure.name), tags)
+	return e.client.Count(name, value, ddTags, 1)
+}
+
+// encodeTags renders name and tags for the wire according to encoding,
+// split out of recordCount so it can be unit tested without a live StatsD
+// connection. It returns the metric name to emit - folding tags into it
+// when encoding is TagEncodingInflux - and the tag slice to pass to the
+// statsd client, which is nil for TagEncodingInflux. Any other encoding,
+// including the empty string, gets Datadog-style "key:value" tags.
+func encodeTags(encoding TagEncoding, name string, tags []statsdTag) (string, []string) {
+	if encoding == TagEncodingInflux {
+		for _, t := range tags {
+			name += fmt.Sprintf(".%s=%s", t.key, t.value)
+		}
+		return name, nil
+	}
+
+	ddTags := make([]string, len(tags))
+	for i, t := range tags {
+		ddTags[i] = fmt.Sprintf("%s:%s", t.key, t.value)
+	}
+	return name, ddTags
+}
+
+// statsdName converts a slash-delimited measure name
+// ("backend/assignments") into StatsD's dotted convention
+// ("backend.assignments").
+func statsdName(name string) string {
+	out := []byte(name)
+	for i, b := range out {
+		if b == '/' {
+			out[i] = '.'
+		}
+	}
+	return string(out)
+}
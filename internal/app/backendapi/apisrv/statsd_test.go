@@ -0,0 +1,66 @@
+/*
+Copyright 2018 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package apisrv
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStatsDName(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"backend/assignments", "backend.assignments"},
+		{"failures", "failures"},
+		{"backend/assignments/deletions/failures", "backend.assignments.deletions.failures"},
+	}
+	for _, c := range cases {
+		if got := statsdName(c.in); got != c.want {
+			t.Errorf("statsdName(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestEncodeTagsDatadog(t *testing.T) {
+	name, tags := encodeTags(TagEncodingDatadog, "backend.assignments", []statsdTag{{"method", "AssignTickets"}})
+	if name != "backend.assignments" {
+		t.Errorf("name = %q, want %q (datadog encoding doesn't touch the name)", name, "backend.assignments")
+	}
+	want := []string{"method:AssignTickets"}
+	if !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+}
+
+func TestEncodeTagsInflux(t *testing.T) {
+	name, tags := encodeTags(TagEncodingInflux, "backend.assignments", []statsdTag{{"method", "AssignTickets"}})
+	wantName := "backend.assignments.method=AssignTickets"
+	if name != wantName {
+		t.Errorf("name = %q, want %q", name, wantName)
+	}
+	if tags != nil {
+		t.Errorf("tags = %v, want nil for influx encoding (tags are folded into the name instead)", tags)
+	}
+}
+
+func TestEncodeTagsNoTags(t *testing.T) {
+	name, tags := encodeTags(TagEncodingDatadog, "failures", nil)
+	if name != "failures" {
+		t.Errorf("name = %q, want %q", name, "failures")
+	}
+	if len(tags) != 0 {
+		t.Errorf("tags = %v, want empty", tags)
+	}
+}